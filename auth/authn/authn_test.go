@@ -0,0 +1,63 @@
+package authn
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// stubAuthenticator lets tests control exactly what a backend in a Chain returns without
+// standing up a real JWT/htpasswd/mTLS backend.
+type stubAuthenticator struct {
+	name      string
+	principal Principal
+	err       error
+}
+
+func (s *stubAuthenticator) Name() string { return s.name }
+
+func (s *stubAuthenticator) Authenticate(r *http.Request) (Principal, error) {
+	return s.principal, s.err
+}
+
+func TestChainReturnsFirstSuccessfulBackend(t *testing.T) {
+	errBackend := errors.New("backend unavailable")
+	chain := NewChain(
+		&stubAuthenticator{name: "jwt", err: errBackend},
+		&stubAuthenticator{name: "htpasswd", principal: Principal{Subject: "alice", Role: "USER"}},
+		&stubAuthenticator{name: "mtls", principal: Principal{Subject: "should-not-be-reached"}},
+	)
+
+	principal, err := chain.Authenticate(httptest.NewRequest(http.MethodGet, "/", nil))
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+
+	if principal.Subject != "alice" {
+		t.Fatalf("Authenticate() = %+v, want the first backend that succeeded", principal)
+	}
+}
+
+func TestChainReturnsLastErrorWhenAllBackendsFail(t *testing.T) {
+	errFirst := errors.New("jwt: missing token")
+	errLast := errors.New("htpasswd: invalid credentials")
+	chain := NewChain(
+		&stubAuthenticator{name: "jwt", err: errFirst},
+		&stubAuthenticator{name: "htpasswd", err: errLast},
+	)
+
+	_, err := chain.Authenticate(httptest.NewRequest(http.MethodGet, "/", nil))
+	if !errors.Is(err, errLast) {
+		t.Fatalf("Authenticate() error = %v, want the last backend's error (%v)", err, errLast)
+	}
+}
+
+func TestChainReturnsErrNoBackendsConfigured(t *testing.T) {
+	chain := NewChain()
+
+	_, err := chain.Authenticate(httptest.NewRequest(http.MethodGet, "/", nil))
+	if !errors.Is(err, ErrNoBackendsConfigured) {
+		t.Fatalf("Authenticate() error = %v, want ErrNoBackendsConfigured", err)
+	}
+}
@@ -0,0 +1,97 @@
+package authn
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/tg123/go-htpasswd"
+)
+
+// HtpasswdAuthenticator authenticates HTTP Basic credentials against an htpasswd file. It
+// watches the file's mtime and reloads it on change so operators can add or remove users
+// without restarting the proxy.
+type HtpasswdAuthenticator struct {
+	path         string
+	defaultRole  string
+	capabilities []string
+
+	mu      sync.RWMutex
+	file    *htpasswd.File
+	modTime time.Time
+}
+
+// NewHtpasswdAuthenticator authenticates against the htpasswd file at path, granting every
+// caller defaultRole and capabilities: every htpasswd user shares the same role and capability
+// set, so an operator who needs finer-grained access should issue that caller a JWT instead.
+func NewHtpasswdAuthenticator(path, defaultRole string, capabilities []string) (*HtpasswdAuthenticator, error) {
+	a := &HtpasswdAuthenticator{path: path, defaultRole: defaultRole, capabilities: capabilities}
+	if err := a.reload(); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+func (a *HtpasswdAuthenticator) Name() string { return "htpasswd" }
+
+func (a *HtpasswdAuthenticator) Authenticate(r *http.Request) (Principal, error) {
+	if err := a.reloadIfChanged(); err != nil {
+		return Principal{}, fmt.Errorf("htpasswd: %w", err)
+	}
+
+	user, pass, ok := r.BasicAuth()
+	if !ok {
+		return Principal{}, fmt.Errorf("htpasswd: missing basic auth credentials")
+	}
+
+	a.mu.RLock()
+	file := a.file
+	a.mu.RUnlock()
+
+	if !file.Match(user, pass) {
+		return Principal{}, fmt.Errorf("htpasswd: invalid credentials")
+	}
+
+	return Principal{Subject: user, Role: a.defaultRole, Capabilities: a.capabilities}, nil
+}
+
+// reloadIfChanged re-parses the htpasswd file only when its mtime has moved forward, so a
+// request pays for a stat but not for a parse unless the file actually changed.
+func (a *HtpasswdAuthenticator) reloadIfChanged() error {
+	info, err := os.Stat(a.path)
+	if err != nil {
+		return err
+	}
+
+	a.mu.RLock()
+	changed := info.ModTime().After(a.modTime)
+	a.mu.RUnlock()
+
+	if !changed {
+		return nil
+	}
+
+	return a.reload()
+}
+
+func (a *HtpasswdAuthenticator) reload() error {
+	file, err := htpasswd.New(a.path, htpasswd.DefaultSystems, nil)
+	if err != nil {
+		return fmt.Errorf("parsing htpasswd file %s: %w", a.path, err)
+	}
+
+	info, err := os.Stat(a.path)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	a.file = file
+	a.modTime = info.ModTime()
+	a.mu.Unlock()
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package authn
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeHtpasswdFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "htpasswd")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("writing htpasswd file: %v", err)
+	}
+
+	return path
+}
+
+func TestHtpasswdAuthenticatorRejectsUnknownUser(t *testing.T) {
+	path := writeHtpasswdFile(t, "# no users configured\n")
+
+	auth, err := NewHtpasswdAuthenticator(path, "USER", []string{"routing:read"})
+	if err != nil {
+		t.Fatalf("NewHtpasswdAuthenticator() error = %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v2/directions", nil)
+	req.SetBasicAuth("nobody", "whatever")
+
+	if _, err := auth.Authenticate(req); err == nil {
+		t.Fatal("Authenticate() error = nil, want an error for a user absent from the file")
+	}
+}
+
+func TestHtpasswdAuthenticatorRejectsMissingCredentials(t *testing.T) {
+	path := writeHtpasswdFile(t, "# no users configured\n")
+
+	auth, err := NewHtpasswdAuthenticator(path, "USER", nil)
+	if err != nil {
+		t.Fatalf("NewHtpasswdAuthenticator() error = %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v2/directions", nil)
+
+	if _, err := auth.Authenticate(req); err == nil {
+		t.Fatal("Authenticate() error = nil, want an error when no Basic auth credentials are presented")
+	}
+}
+
+// TestHtpasswdAuthenticatorReloadIsGatedByModTime exercises reloadIfChanged directly: it must
+// skip re-parsing the file when its mtime hasn't moved forward (the common case, checked on
+// every request), and must re-parse once the mtime advances, so added/removed users take effect
+// without a restart.
+func TestHtpasswdAuthenticatorReloadIsGatedByModTime(t *testing.T) {
+	path := writeHtpasswdFile(t, "# revision 1\n")
+
+	auth, err := NewHtpasswdAuthenticator(path, "USER", nil)
+	if err != nil {
+		t.Fatalf("NewHtpasswdAuthenticator() error = %v", err)
+	}
+
+	loadedFile := auth.file
+	loadedModTime := auth.modTime
+
+	// Rewrite the file's contents but pin its mtime back to what it was: reloadIfChanged should
+	// see no advance and skip re-parsing.
+	if err := os.WriteFile(path, []byte("# revision 2\n"), 0o600); err != nil {
+		t.Fatalf("rewriting htpasswd file: %v", err)
+	}
+	if err := os.Chtimes(path, loadedModTime, loadedModTime); err != nil {
+		t.Fatalf("resetting htpasswd file mtime: %v", err)
+	}
+
+	if err := auth.reloadIfChanged(); err != nil {
+		t.Fatalf("reloadIfChanged() error = %v", err)
+	}
+	if auth.file != loadedFile {
+		t.Fatal("reloadIfChanged() re-parsed the file even though its mtime didn't advance")
+	}
+
+	// Now advance the mtime: reloadIfChanged should re-parse.
+	newModTime := loadedModTime.Add(time.Second)
+	if err := os.Chtimes(path, newModTime, newModTime); err != nil {
+		t.Fatalf("advancing htpasswd file mtime: %v", err)
+	}
+
+	if err := auth.reloadIfChanged(); err != nil {
+		t.Fatalf("reloadIfChanged() error = %v", err)
+	}
+	if auth.file == loadedFile {
+		t.Fatal("reloadIfChanged() did not re-parse the file after its mtime advanced")
+	}
+}
@@ -0,0 +1,72 @@
+package authn
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// UserJwtClaims mirrors the user row fields embedded in the proxy's JWTs.
+type UserJwtClaims struct {
+	Id        int            `json:"id" db:"id"`
+	FirstName sql.NullString `json:"first_name" db:"first_name"`
+	LastName  sql.NullString `json:"last_name" db:"last_name"`
+	Role      string         `json:"role" db:"role"`
+}
+
+type MyCustomClaims struct {
+	UserJwtClaims
+	Type         string   `json:"type"`
+	Capabilities []string `json:"capabilities,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// JWTAuthenticator validates the HMAC-signed bearer token issued to internal services.
+type JWTAuthenticator struct {
+	Secret       []byte
+	AllowedRoles []string
+}
+
+func NewJWTAuthenticator(secret []byte, allowedRoles ...string) *JWTAuthenticator {
+	return &JWTAuthenticator{Secret: secret, AllowedRoles: allowedRoles}
+}
+
+func (a *JWTAuthenticator) Name() string { return "jwt" }
+
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (Principal, error) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		return Principal{}, fmt.Errorf("jwt: missing token")
+	}
+
+	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+
+	token, err := jwt.ParseWithClaims(tokenString, &MyCustomClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method")
+		}
+
+		return a.Secret, nil
+	})
+
+	if err != nil || !token.Valid {
+		return Principal{}, fmt.Errorf("jwt: invalid token: %w", err)
+	}
+
+	// Extract claims and validate role
+	claims, ok := token.Claims.(*MyCustomClaims)
+	if !ok || !Contains(a.AllowedRoles, claims.Role) {
+		return Principal{}, fmt.Errorf("jwt: role %q not permitted", claims.Role)
+	}
+
+	return Principal{
+		Id:           claims.Id,
+		Subject:      strconv.Itoa(claims.Id),
+		Role:         claims.Role,
+		Capabilities: claims.Capabilities,
+	}, nil
+}
@@ -0,0 +1,84 @@
+package authn
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func signToken(t *testing.T, secret []byte, claims MyCustomClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString(secret)
+	if err != nil {
+		t.Fatalf("signing test token: %v", err)
+	}
+
+	return signed
+}
+
+func TestJWTAuthenticatorExtractsRoleAndCapabilities(t *testing.T) {
+	secret := []byte("test-secret")
+	auth := NewJWTAuthenticator(secret, "ADMIN", "USER")
+
+	claims := MyCustomClaims{
+		UserJwtClaims: UserJwtClaims{Id: 42, Role: "ADMIN"},
+		Capabilities:  []string{"routing:read", "routing:write"},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v2/directions", nil)
+	req.Header.Set("Authorization", "Bearer "+signToken(t, secret, claims))
+
+	principal, err := auth.Authenticate(req)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+
+	if principal.Id != 42 || principal.Subject != "42" || principal.Role != "ADMIN" {
+		t.Fatalf("Authenticate() = %+v, want Id=42 Subject=42 Role=ADMIN", principal)
+	}
+
+	if len(principal.Capabilities) != 2 || principal.Capabilities[0] != "routing:read" {
+		t.Fatalf("Authenticate() Capabilities = %v, want [routing:read routing:write]", principal.Capabilities)
+	}
+}
+
+func TestJWTAuthenticatorRejectsDisallowedRole(t *testing.T) {
+	secret := []byte("test-secret")
+	auth := NewJWTAuthenticator(secret, "ADMIN")
+
+	claims := MyCustomClaims{UserJwtClaims: UserJwtClaims{Id: 1, Role: "CLIENT"}}
+
+	req := httptest.NewRequest(http.MethodGet, "/v2/directions", nil)
+	req.Header.Set("Authorization", "Bearer "+signToken(t, secret, claims))
+
+	if _, err := auth.Authenticate(req); err == nil {
+		t.Fatal("Authenticate() error = nil, want an error for a role not in AllowedRoles")
+	}
+}
+
+func TestJWTAuthenticatorRejectsWrongSigningSecret(t *testing.T) {
+	auth := NewJWTAuthenticator([]byte("real-secret"), "ADMIN")
+
+	claims := MyCustomClaims{UserJwtClaims: UserJwtClaims{Id: 1, Role: "ADMIN"}}
+
+	req := httptest.NewRequest(http.MethodGet, "/v2/directions", nil)
+	req.Header.Set("Authorization", "Bearer "+signToken(t, []byte("wrong-secret"), claims))
+
+	if _, err := auth.Authenticate(req); err == nil {
+		t.Fatal("Authenticate() error = nil, want an error for a token signed with the wrong secret")
+	}
+}
+
+func TestJWTAuthenticatorRejectsMissingToken(t *testing.T) {
+	auth := NewJWTAuthenticator([]byte("test-secret"), "ADMIN")
+
+	req := httptest.NewRequest(http.MethodGet, "/v2/directions", nil)
+
+	if _, err := auth.Authenticate(req); err == nil {
+		t.Fatal("Authenticate() error = nil, want an error when Authorization is missing")
+	}
+}
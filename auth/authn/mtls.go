@@ -0,0 +1,39 @@
+package authn
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// MTLSAuthenticator trusts the client certificate the TLS handshake already verified and maps
+// its CN (falling back to the first DNS SAN) to a role via RoleMappings, and that role to a set
+// of capabilities via RoleCapabilities.
+type MTLSAuthenticator struct {
+	RoleMappings     map[string]string
+	RoleCapabilities map[string][]string
+}
+
+func NewMTLSAuthenticator(roleMappings map[string]string, roleCapabilities map[string][]string) *MTLSAuthenticator {
+	return &MTLSAuthenticator{RoleMappings: roleMappings, RoleCapabilities: roleCapabilities}
+}
+
+func (a *MTLSAuthenticator) Name() string { return "mtls" }
+
+func (a *MTLSAuthenticator) Authenticate(r *http.Request) (Principal, error) {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return Principal{}, fmt.Errorf("mtls: no client certificate presented")
+	}
+
+	cert := r.TLS.PeerCertificates[0]
+	identity := cert.Subject.CommonName
+	if identity == "" && len(cert.DNSNames) > 0 {
+		identity = cert.DNSNames[0]
+	}
+
+	role, ok := a.RoleMappings[identity]
+	if !ok {
+		return Principal{}, fmt.Errorf("mtls: no role mapped for identity %q", identity)
+	}
+
+	return Principal{Subject: identity, Role: role, Capabilities: a.RoleCapabilities[role]}, nil
+}
@@ -0,0 +1,74 @@
+package authn
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func requestWithPeerCert(cert *x509.Certificate) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/v2/directions", nil)
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+
+	return req
+}
+
+func TestMTLSAuthenticatorMapsCommonNameToRole(t *testing.T) {
+	auth := NewMTLSAuthenticator(
+		map[string]string{"courier-1.internal": "COURIER"},
+		map[string][]string{"COURIER": {"routing:read"}},
+	)
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "courier-1.internal"}}
+
+	principal, err := auth.Authenticate(requestWithPeerCert(cert))
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+
+	if principal.Subject != "courier-1.internal" || principal.Role != "COURIER" {
+		t.Fatalf("Authenticate() = %+v, want Subject=courier-1.internal Role=COURIER", principal)
+	}
+
+	if len(principal.Capabilities) != 1 || principal.Capabilities[0] != "routing:read" {
+		t.Fatalf("Authenticate() Capabilities = %v, want [routing:read]", principal.Capabilities)
+	}
+}
+
+func TestMTLSAuthenticatorFallsBackToDNSSAN(t *testing.T) {
+	auth := NewMTLSAuthenticator(map[string]string{"courier-1.internal": "COURIER"}, nil)
+
+	cert := &x509.Certificate{DNSNames: []string{"courier-1.internal"}}
+
+	principal, err := auth.Authenticate(requestWithPeerCert(cert))
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+
+	if principal.Role != "COURIER" {
+		t.Fatalf("Authenticate() Role = %q, want COURIER", principal.Role)
+	}
+}
+
+func TestMTLSAuthenticatorRejectsUnmappedIdentity(t *testing.T) {
+	auth := NewMTLSAuthenticator(map[string]string{"courier-1.internal": "COURIER"}, nil)
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "unknown.internal"}}
+
+	if _, err := auth.Authenticate(requestWithPeerCert(cert)); err == nil {
+		t.Fatal("Authenticate() error = nil, want an error for an identity with no role mapping")
+	}
+}
+
+func TestMTLSAuthenticatorRejectsMissingClientCert(t *testing.T) {
+	auth := NewMTLSAuthenticator(map[string]string{"courier-1.internal": "COURIER"}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/v2/directions", nil)
+
+	if _, err := auth.Authenticate(req); err == nil {
+		t.Fatal("Authenticate() error = nil, want an error when no client certificate was presented")
+	}
+}
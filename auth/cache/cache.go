@@ -0,0 +1,69 @@
+// Package cache caches idempotent upstream responses so repeat ORS queries don't eat into the
+// (often strict) daily/second quota a single upstream key shares across every caller.
+package cache
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Entry is a cached upstream response, along with enough bookkeeping to reconstruct an Age
+// header and know when it's stale.
+type Entry struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	StoredAt   time.Time
+	ExpiresAt  time.Time
+}
+
+func (e *Entry) Expired(now time.Time) bool {
+	return now.After(e.ExpiresAt)
+}
+
+func (e *Entry) Age(now time.Time) time.Duration {
+	return now.Sub(e.StoredAt)
+}
+
+// Store is the minimal interface a cache backend must implement. InProcessStore and RedisStore
+// both satisfy it, so proxyHandler's callers don't need to know which one is active.
+type Store interface {
+	Get(key string) (*Entry, bool)
+	Set(key string, entry *Entry)
+}
+
+// InProcessStore is a process-local cache, good enough for a single proxy instance.
+type InProcessStore struct {
+	mu      sync.RWMutex
+	entries map[string]*Entry
+}
+
+func NewInProcessStore() *InProcessStore {
+	return &InProcessStore{entries: make(map[string]*Entry)}
+}
+
+func (s *InProcessStore) Get(key string) (*Entry, bool) {
+	s.mu.RLock()
+	entry, ok := s.entries[key]
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, false
+	}
+
+	if entry.Expired(time.Now()) {
+		s.mu.Lock()
+		delete(s.entries, key)
+		s.mu.Unlock()
+		return nil, false
+	}
+
+	return entry, true
+}
+
+func (s *InProcessStore) Set(key string, entry *Entry) {
+	s.mu.Lock()
+	s.entries[key] = entry
+	s.mu.Unlock()
+}
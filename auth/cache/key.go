@@ -0,0 +1,38 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Key builds a stable cache key from method, path, a normalized (sorted) query string, and a
+// hash of the body, so equivalent requests collapse to the same entry regardless of query
+// parameter order or value ordering.
+func Key(method, path, rawQuery string, body []byte) string {
+	values, _ := url.ParseQuery(rawQuery)
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var normalizedQuery strings.Builder
+	for i, name := range names {
+		if i > 0 {
+			normalizedQuery.WriteByte('&')
+		}
+
+		sort.Strings(values[name])
+		normalizedQuery.WriteString(name)
+		normalizedQuery.WriteByte('=')
+		normalizedQuery.WriteString(strings.Join(values[name], ","))
+	}
+
+	bodyHash := sha256.Sum256(body)
+
+	return method + " " + path + "?" + normalizedQuery.String() + "#" + hex.EncodeToString(bodyHash[:])
+}
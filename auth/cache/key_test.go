@@ -0,0 +1,29 @@
+package cache
+
+import "testing"
+
+func TestKeyIgnoresQueryParameterOrder(t *testing.T) {
+	a := Key("GET", "/v2/directions", "b=2&a=1", nil)
+	b := Key("GET", "/v2/directions", "a=1&b=2", nil)
+
+	if a != b {
+		t.Fatalf("Key() = %q, %q; want matching keys regardless of query parameter order", a, b)
+	}
+}
+
+func TestKeyDiffersOnMethodPathQueryOrBody(t *testing.T) {
+	base := Key("POST", "/v2/matrix", "profile=car", []byte(`{"locations":[]}`))
+
+	cases := map[string]string{
+		"method": Key("GET", "/v2/matrix", "profile=car", []byte(`{"locations":[]}`)),
+		"path":   Key("POST", "/v2/isochrones", "profile=car", []byte(`{"locations":[]}`)),
+		"query":  Key("POST", "/v2/matrix", "profile=bike", []byte(`{"locations":[]}`)),
+		"body":   Key("POST", "/v2/matrix", "profile=car", []byte(`{"locations":[1]}`)),
+	}
+
+	for name, other := range cases {
+		if other == base {
+			t.Errorf("Key() with a different %s collided with the base key", name)
+		}
+	}
+}
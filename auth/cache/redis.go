@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"main/logger"
+)
+
+// RedisStore backs the cache with Redis so multiple proxy instances share one cache instead of
+// each warming its own in-process copy.
+type RedisStore struct {
+	client *redis.Client
+	logs   logger.LoggerInterface
+}
+
+func NewRedisStore(addr string, logs logger.LoggerInterface) *RedisStore {
+	return &RedisStore{client: redis.NewClient(&redis.Options{Addr: addr}), logs: logs}
+}
+
+// redisEntry is Entry's JSON wire representation; Header is carried as a plain
+// map[string][]string, the type underlying http.Header.
+type redisEntry struct {
+	StatusCode int
+	Header     map[string][]string
+	Body       []byte
+	StoredAt   time.Time
+	ExpiresAt  time.Time
+}
+
+func (s *RedisStore) Get(key string) (*Entry, bool) {
+	data, err := s.client.Get(context.Background(), key).Bytes()
+	if err != nil {
+		return nil, false
+	}
+
+	var re redisEntry
+	if err := json.Unmarshal(data, &re); err != nil {
+		return nil, false
+	}
+
+	entry := &Entry{
+		StatusCode: re.StatusCode,
+		Header:     http.Header(re.Header),
+		Body:       re.Body,
+		StoredAt:   re.StoredAt,
+		ExpiresAt:  re.ExpiresAt,
+	}
+
+	if entry.Expired(time.Now()) {
+		return nil, false
+	}
+
+	return entry, true
+}
+
+func (s *RedisStore) Set(key string, entry *Entry) {
+	ttl := time.Until(entry.ExpiresAt)
+	if ttl <= 0 {
+		return
+	}
+
+	data, err := json.Marshal(redisEntry{
+		StatusCode: entry.StatusCode,
+		Header:     map[string][]string(entry.Header),
+		Body:       entry.Body,
+		StoredAt:   entry.StoredAt,
+		ExpiresAt:  entry.ExpiresAt,
+	})
+	if err != nil {
+		return
+	}
+
+	if err := s.client.Set(context.Background(), key, data, ttl).Err(); err != nil {
+		s.logs.ErrorF("Error writing cache entry to redis: %v", err)
+	}
+}
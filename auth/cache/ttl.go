@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TTL determines how long to cache a response: an upstream Cache-Control max-age wins, then
+// Expires, falling back to defaultTTL when neither is present or parseable.
+func TTL(header http.Header, defaultTTL time.Duration) time.Duration {
+	if cacheControl := header.Get("Cache-Control"); cacheControl != "" {
+		for _, directive := range strings.Split(cacheControl, ",") {
+			directive = strings.TrimSpace(directive)
+			if seconds, ok := strings.CutPrefix(directive, "max-age="); ok {
+				if n, err := strconv.Atoi(seconds); err == nil {
+					return time.Duration(n) * time.Second
+				}
+			}
+		}
+	}
+
+	if expires := header.Get("Expires"); expires != "" {
+		if t, err := http.ParseTime(expires); err == nil {
+			if ttl := time.Until(t); ttl > 0 {
+				return ttl
+			}
+		}
+	}
+
+	return defaultTTL
+}
+
+// NoStore reports whether header carries Cache-Control: no-store.
+func NoStore(header http.Header) bool {
+	return hasDirective(header.Get("Cache-Control"), "no-store")
+}
+
+// NoCache reports whether header carries Cache-Control: no-cache.
+func NoCache(header http.Header) bool {
+	return hasDirective(header.Get("Cache-Control"), "no-cache")
+}
+
+func hasDirective(cacheControl, directive string) bool {
+	for _, d := range strings.Split(cacheControl, ",") {
+		if strings.EqualFold(strings.TrimSpace(d), directive) {
+			return true
+		}
+	}
+
+	return false
+}
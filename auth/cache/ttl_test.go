@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestTTLPrefersCacheControlMaxAge(t *testing.T) {
+	header := http.Header{"Cache-Control": []string{"public, max-age=120"}}
+
+	if got, want := TTL(header, 30*time.Second), 120*time.Second; got != want {
+		t.Errorf("TTL() = %v, want %v", got, want)
+	}
+}
+
+func TestTTLFallsBackToExpires(t *testing.T) {
+	header := http.Header{"Expires": []string{time.Now().Add(5 * time.Minute).UTC().Format(http.TimeFormat)}}
+
+	got := TTL(header, 30*time.Second)
+	if got <= 4*time.Minute || got > 5*time.Minute {
+		t.Errorf("TTL() = %v, want ~5m derived from Expires", got)
+	}
+}
+
+func TestTTLFallsBackToDefault(t *testing.T) {
+	if got, want := TTL(http.Header{}, 30*time.Second), 30*time.Second; got != want {
+		t.Errorf("TTL() = %v, want %v", got, want)
+	}
+}
+
+func TestNoStoreAndNoCache(t *testing.T) {
+	header := http.Header{"Cache-Control": []string{"no-store"}}
+	if !NoStore(header) {
+		t.Errorf("NoStore() = false, want true for Cache-Control: no-store")
+	}
+	if NoCache(header) {
+		t.Errorf("NoCache() = true, want false for Cache-Control: no-store")
+	}
+
+	header = http.Header{"Cache-Control": []string{"no-cache"}}
+	if !NoCache(header) {
+		t.Errorf("NoCache() = false, want true for Cache-Control: no-cache")
+	}
+}
@@ -2,23 +2,79 @@ package logger
 
 import (
 	"fmt"
-	"log"
+	"io"
 	"log/slog"
-	"os"
+	"strings"
 	"sync"
+
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
+// LevelConfig controls the rotation behavior of the file backing a single log level.
+type LevelConfig struct {
+	Filename   string
+	MaxSizeMB  int
+	MaxAgeDays int
+	MaxBackups int
+	Compress   bool
+	LocalTime  bool
+}
+
+// Config configures the destinations, rotation policy, and output format for a Logger.
+type Config struct {
+	Error  LevelConfig
+	Warn   LevelConfig
+	Info   LevelConfig
+	Access LevelConfig
+
+	// Format selects the slog handler used for the error, warn and info destinations: "text"
+	// (default) or "json". Access records are written as-is, whatever the Format.
+	Format string
+
+	// MinLevel filters out records below this level: "debug", "info", "warn", or "error".
+	MinLevel string
+}
+
+// DefaultConfig returns the rotation policy used when nothing else is configured: four
+// plain-text logs under logger/ (errors, warnings, info, access), each rotated at 100MB and
+// keeping at most 7 backups for up to 28 days.
+func DefaultConfig() Config {
+	defaultLevel := LevelConfig{MaxSizeMB: 100, MaxAgeDays: 28, MaxBackups: 7}
+
+	errCfg := defaultLevel
+	errCfg.Filename = "logger/errors.log"
+
+	warnCfg := defaultLevel
+	warnCfg.Filename = "logger/warnings.log"
+
+	infoCfg := defaultLevel
+	infoCfg.Filename = "logger/info.log"
+
+	accessCfg := defaultLevel
+	accessCfg.Filename = "logger/access.log"
+
+	return Config{
+		Error:  errCfg,
+		Warn:   warnCfg,
+		Info:   infoCfg,
+		Access: accessCfg,
+		Format: "text",
+	}
+}
+
 type Logger struct {
-	errChan  chan error
-	warnChan chan string
-	infoChan chan string
+	cfg Config
+
+	errChan    chan error
+	warnChan   chan string
+	infoChan   chan string
+	accessChan chan string
 
 	once sync.Once
 }
 
 type LoggerInterface interface {
 	StartListener()
-	OpenLogFile(logFileName string, flag int, perm os.FileMode) *os.File
 	Error(err error)
 	ErrorF(err string, args ...any)
 	ErrorStr(err string, args ...any)
@@ -26,13 +82,16 @@ type LoggerInterface interface {
 	Info(info string)
 	InfoF(info string, args ...any)
 	WarnF(warning string, args ...any)
+	Access(record string)
 }
 
-func NewLogger() LoggerInterface {
+func NewLogger(cfg Config) LoggerInterface {
 	return &Logger{
-		errChan:  make(chan error, 100),
-		warnChan: make(chan string, 100),
-		infoChan: make(chan string, 100),
+		cfg:        cfg,
+		errChan:    make(chan error, 100),
+		warnChan:   make(chan string, 100),
+		infoChan:   make(chan string, 100),
+		accessChan: make(chan string, 100),
 	}
 }
 
@@ -46,14 +105,14 @@ func (l *Logger) StartListener() {
 	// Make sure listener is initialized only once
 	l.once.Do(func() {
 		// TODO: Potentially need to configure stout logger for dev env
-		errLogFile := l.OpenLogFile("logger/errors.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-		warnLogFile := l.OpenLogFile("logger/warnings.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-		infoLogFile := l.OpenLogFile("logger/info.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		opts := &slog.HandlerOptions{Level: parseLevel(l.cfg.MinLevel)}
 
-		// Create logger instances
-		errLogger := slog.New(slog.NewTextHandler(errLogFile, nil))
-		warnLogger := slog.New(slog.NewTextHandler(warnLogFile, nil))
-		infoLogger := slog.New(slog.NewTextHandler(infoLogFile, nil))
+		errLogger := slog.New(l.newHandler(rotatingWriter(l.cfg.Error), opts))
+		warnLogger := slog.New(l.newHandler(rotatingWriter(l.cfg.Warn), opts))
+		infoLogger := slog.New(l.newHandler(rotatingWriter(l.cfg.Info), opts))
+		// Access records arrive pre-formatted (CLF/Combined/JSON), so they're written as-is
+		// rather than through a slog handler.
+		accessWriter := rotatingWriter(l.cfg.Access)
 
 		// Listen to ErrChan
 		go func(el, wl, il *slog.Logger) {
@@ -71,19 +130,48 @@ func (l *Logger) StartListener() {
 				case info := <-l.infoChan:
 					il.Info(info)
 					fmt.Println(info)
+				case record := <-l.accessChan:
+					fmt.Fprintln(accessWriter, record)
+					fmt.Println(record)
 				}
 			}
 		}(errLogger, warnLogger, infoLogger)
 	})
 }
 
-func (l *Logger) OpenLogFile(logFileName string, flag int, perm os.FileMode) *os.File {
-	logFile, err := os.OpenFile(logFileName, flag, perm)
-	if err != nil {
-		log.Fatal(fmt.Sprintf("Could not open log file: %s, %v", logFileName, err))
+// rotatingWriter wraps a LevelConfig in a lumberjack writer that renames the current file once it
+// exceeds MaxSizeMB, gzips rotated files when Compress is set, and prunes by MaxAgeDays/MaxBackups.
+func rotatingWriter(lc LevelConfig) io.Writer {
+	return &lumberjack.Logger{
+		Filename:   lc.Filename,
+		MaxSize:    lc.MaxSizeMB,
+		MaxAge:     lc.MaxAgeDays,
+		MaxBackups: lc.MaxBackups,
+		Compress:   lc.Compress,
+		LocalTime:  lc.LocalTime,
+	}
+}
+
+// newHandler picks the slog handler for the configured output Format.
+func (l *Logger) newHandler(w io.Writer, opts *slog.HandlerOptions) slog.Handler {
+	if strings.EqualFold(l.cfg.Format, "json") {
+		return slog.NewJSONHandler(w, opts)
 	}
 
-	return logFile
+	return slog.NewTextHandler(w, opts)
+}
+
+func parseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
 }
 
 func (l *Logger) Error(err error) {
@@ -113,3 +201,7 @@ func (l *Logger) Info(info string) {
 func (l *Logger) InfoF(info string, args ...any) {
 	l.infoChan <- fmt.Sprintf(info, args...)
 }
+
+func (l *Logger) Access(record string) {
+	l.accessChan <- record
+}
@@ -1,111 +1,617 @@
 package main
 
 import (
-	"database/sql"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
-	"github.com/golang-jwt/jwt/v4"
+	"golang.org/x/sync/singleflight"
 	"io"
 	"log"
+	"main/authn"
+	"main/cache"
 	"main/logger"
+	"main/ratelimit"
+	"main/rules"
+	"net"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 )
 
-func In[T comparable](item T, items ...T) bool {
-	for _, i := range items {
-		if i == item {
-			return true
-		}
-	}
+// region Context
 
-	return false
-}
+type contextKey string
 
-// region Claims
+const principalContextKey contextKey = "principal"
+const ruleContextKey contextKey = "rule"
 
-type UserJwtClaims struct {
-	Id        int            `json:"id" db:"id"`
-	FirstName sql.NullString `json:"first_name" db:"first_name"`
-	LastName  sql.NullString `json:"last_name" db:"last_name"`
-	Role      string         `json:"role" db:"role"`
+func principalFromContext(ctx context.Context) (authn.Principal, bool) {
+	principal, ok := ctx.Value(principalContextKey).(authn.Principal)
+	return principal, ok
 }
 
-type MyCustomClaims struct {
-	UserJwtClaims
-	Type string `json:"type"`
-	jwt.RegisteredClaims
+func ruleFromContext(ctx context.Context) (rules.Rule, bool) {
+	rule, ok := ctx.Value(ruleContextKey).(rules.Rule)
+	return rule, ok
 }
 
 // endregion
 
 var jwtSecret []byte
-var targetDomain string
 var logs logger.LoggerInterface
+var authenticator authn.Authenticator
+var rulesRouter *rules.Router
+var responseCache cache.Store
+var cacheDefaultTTL time.Duration
+var cacheGroup singleflight.Group
+var rateLimiter *ratelimit.Limiter
+
+// defaultRateLimitConfig is used when RATE_LIMIT_FILE isn't set: reasonable per-role limits so
+// the proxy enforces something out of the box instead of being wide open.
+func defaultRateLimitConfig() ratelimit.Config {
+	return ratelimit.Config{
+		Global: ratelimit.Limit{RPS: 20, Burst: 40},
+		Roles: map[string]ratelimit.Limit{
+			"ADMIN":   {RPS: 50, Burst: 100},
+			"MANAGER": {RPS: 20, Burst: 40},
+			"COURIER": {RPS: 10, Burst: 20},
+			"CLIENT":  {RPS: 5, Burst: 10},
+			"VENDOR":  {RPS: 10, Burst: 20},
+			"USER":    {RPS: 10, Burst: 20},
+		},
+	}
+}
+
+// loggerConfigFromEnv builds the logger.Config from the environment, falling back to
+// logger.DefaultConfig() for anything left unset so operators can opt into rotation tuning
+// and JSON output without touching code.
+func loggerConfigFromEnv() logger.Config {
+	cfg := logger.DefaultConfig()
+
+	if format := os.Getenv("LOG_FORMAT"); format != "" {
+		cfg.Format = format
+	}
+
+	if minLevel := os.Getenv("LOG_MIN_LEVEL"); minLevel != "" {
+		cfg.MinLevel = minLevel
+	}
+
+	if maxSizeMB, err := strconv.Atoi(os.Getenv("LOG_MAX_SIZE_MB")); err == nil {
+		cfg.Error.MaxSizeMB = maxSizeMB
+		cfg.Warn.MaxSizeMB = maxSizeMB
+		cfg.Info.MaxSizeMB = maxSizeMB
+		cfg.Access.MaxSizeMB = maxSizeMB
+	}
+
+	if maxAgeDays, err := strconv.Atoi(os.Getenv("LOG_MAX_AGE_DAYS")); err == nil {
+		cfg.Error.MaxAgeDays = maxAgeDays
+		cfg.Warn.MaxAgeDays = maxAgeDays
+		cfg.Info.MaxAgeDays = maxAgeDays
+		cfg.Access.MaxAgeDays = maxAgeDays
+	}
+
+	if maxBackups, err := strconv.Atoi(os.Getenv("LOG_MAX_BACKUPS")); err == nil {
+		cfg.Error.MaxBackups = maxBackups
+		cfg.Warn.MaxBackups = maxBackups
+		cfg.Info.MaxBackups = maxBackups
+		cfg.Access.MaxBackups = maxBackups
+	}
+
+	if compress, err := strconv.ParseBool(os.Getenv("LOG_COMPRESS")); err == nil {
+		cfg.Error.Compress = compress
+		cfg.Warn.Compress = compress
+		cfg.Info.Compress = compress
+		cfg.Access.Compress = compress
+	}
+
+	return cfg
+}
+
+// region Auth
+
+// buildAuthenticator assembles the Authenticator chain named by AUTH_BACKENDS (comma-separated,
+// tried in order until one succeeds), e.g. "jwt,htpasswd" to accept internal service JWTs and
+// fall back to htpasswd Basic auth for operators. Defaults to "jwt" alone to preserve the
+// proxy's original behavior.
+func buildAuthenticator() (authn.Authenticator, error) {
+	var backends []authn.Authenticator
+	for _, name := range authBackendNames() {
+		switch name {
+		case "jwt":
+			backends = append(backends, authn.NewJWTAuthenticator(
+				jwtSecret, "ADMIN", "USER", "COURIER", "MANAGER", "CLIENT", "VENDOR"))
+		case "htpasswd":
+			htpasswdAuth, err := authn.NewHtpasswdAuthenticator(
+				os.Getenv("HTPASSWD_FILE"), os.Getenv("HTPASSWD_DEFAULT_ROLE"), parseCapabilities(os.Getenv("HTPASSWD_CAPABILITIES")))
+			if err != nil {
+				return nil, fmt.Errorf("htpasswd backend: %w", err)
+			}
+
+			backends = append(backends, htpasswdAuth)
+		case "mtls":
+			backends = append(backends, authn.NewMTLSAuthenticator(
+				parseRoleMap(os.Getenv("MTLS_ROLE_MAP")), parseRoleCapabilities(os.Getenv("MTLS_ROLE_CAPABILITIES"))))
+		default:
+			return nil, fmt.Errorf("unknown auth backend %q", name)
+		}
+	}
+
+	return authn.NewChain(backends...), nil
+}
+
+// authBackendNames parses AUTH_BACKENDS into a trimmed, non-empty list of backend names,
+// defaulting to "jwt" alone to preserve the proxy's original behavior. buildTLSConfig reuses this
+// instead of re-parsing AUTH_BACKENDS itself, so the two can't disagree on what's configured.
+func authBackendNames() []string {
+	raw := os.Getenv("AUTH_BACKENDS")
+	if raw == "" {
+		raw = "jwt"
+	}
+
+	var names []string
+	for _, name := range strings.Split(raw, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// parseRoleMap parses MTLS_ROLE_MAP entries of the form "identity:ROLE,identity:ROLE", where
+// identity is a client certificate's CN or SAN.
+func parseRoleMap(raw string) map[string]string {
+	roleMap := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		identity, role, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok {
+			continue
+		}
+
+		roleMap[identity] = role
+	}
+
+	return roleMap
+}
+
+// parseCapabilities parses a comma-separated list of "resource:verb" capabilities, as granted
+// to every caller of the htpasswd backend via HTPASSWD_CAPABILITIES.
+func parseCapabilities(raw string) []string {
+	var capabilities []string
+	for _, capability := range strings.Split(raw, ",") {
+		if capability = strings.TrimSpace(capability); capability != "" {
+			capabilities = append(capabilities, capability)
+		}
+	}
+
+	return capabilities
+}
+
+// parseRoleCapabilities parses MTLS_ROLE_CAPABILITIES entries of the form
+// "ROLE=resource:verb;resource:verb,ROLE=resource:verb", mapping each role produced by
+// MTLS_ROLE_MAP to the capabilities it's granted.
+func parseRoleCapabilities(raw string) map[string][]string {
+	capsByRole := make(map[string][]string)
+	for _, pair := range strings.Split(raw, ",") {
+		role, capabilities, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			continue
+		}
+
+		capsByRole[role] = parseCapabilities(strings.ReplaceAll(capabilities, ";", ","))
+	}
+
+	return capsByRole
+}
+
+// buildTLSConfig enables client certificate verification when the mTLS backend is configured,
+// since http.ListenAndServeTLS alone never asks the client for one.
+func buildTLSConfig() (*tls.Config, error) {
+	if !authn.Contains(authBackendNames(), "mtls") {
+		return nil, nil
+	}
+
+	caPath := os.Getenv("MTLS_CLIENT_CA_PATH")
+	caPEM, err := os.ReadFile(caPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading mTLS client CA %s: %w", caPath, err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificates found in %s", caPath)
+	}
+
+	return &tls.Config{ClientCAs: pool, ClientAuth: tls.RequireAndVerifyClientCert}, nil
+}
+
+// endregion
 
 // region Middleware
 
-// Middleware to validate JWT token
-func validateJWT(next http.Handler) http.Handler {
+// authenticate tries each configured Authenticator backend in order and stashes the resulting
+// Principal into the request context for downstream handlers. It also records the Principal on
+// the access log's statusResponseWriter, since accessLogMiddleware runs outside this handler
+// and never sees the derived request.
+func authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "Missing token", http.StatusUnauthorized)
+		principal, err := authenticator.Authenticate(r)
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
 
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-
-		token, err := jwt.ParseWithClaims(tokenString, &MyCustomClaims{}, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method")
-			}
+		if sw, ok := w.(*statusResponseWriter); ok {
+			sw.principal = &principal
+		}
 
-			return jwtSecret, nil
-		})
+		ctx := context.WithValue(r.Context(), principalContextKey, principal)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
 
-		if err != nil || !token.Valid {
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
+// routeMiddleware dispatches the request against the configured rules, in place of the proxy's
+// former single catch-all route, and denies it unless the authenticated Principal's role and
+// capabilities clear the bar the matching Rule sets.
+func routeMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rule, ok := rulesRouter.Match(r.Method, r.URL.Path)
+		if !ok {
+			http.Error(w, "Not Found", http.StatusNotFound)
 			return
 		}
 
-		// Extract claims and validate role
-		claims, ok := token.Claims.(*MyCustomClaims)
-		if !ok || !In(claims.Role, "ADMIN", "USER", "COURIER", "MANAGER", "CLIENT", "VENDOR") {
+		principal, _ := principalFromContext(r.Context())
+		if !rule.Allows(principal, r.Method) {
 			http.Error(w, "Forbidden", http.StatusForbidden)
 			return
 		}
 
+		ctx := context.WithValue(r.Context(), ruleContextKey, rule)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// rateLimitMiddleware enforces rateLimiter against the authenticated principal and the matched
+// Rule's path prefix, plus a global per-IP bucket, rejecting with 429 once either is exhausted.
+func rateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rule, _ := ruleFromContext(r.Context())
+		principal, _ := principalFromContext(r.Context())
+
+		remoteIP := r.RemoteAddr
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			remoteIP = host
+		}
+
+		result := rateLimiter.Allow(principal.Subject, principal.Role, rule.PathPrefix, remoteIP)
+
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+
+		if !result.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(result.RetryAfter.Seconds())+1))
+			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+			logs.WarnF("Rate limit exceeded for principal %s (role %s) on route %s", principal.Subject, principal.Role, rule.PathPrefix)
+			return
+		}
+
 		next.ServeHTTP(w, r)
 	})
 }
 
+// cacheMiddleware serves idempotent GET/POST requests for a Rule marked Cacheable out of
+// responseCache, and otherwise lets exactly one concurrent request per cache key reach next,
+// via cacheGroup, while the rest wait on its result instead of all hitting the upstream.
+func cacheMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rule, _ := ruleFromContext(r.Context())
+		if !rule.Cacheable || !authn.Contains([]string{http.MethodGet, http.MethodPost}, r.Method) || cache.NoStore(r.Header) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var body []byte
+		if r.Body != nil {
+			var err error
+			body, err = io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, "Error reading request body", http.StatusInternalServerError)
+				logs.ErrorF("Error reading request body: %v", err)
+				return
+			}
+
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		key := cache.Key(r.Method, r.URL.Path, r.URL.RawQuery, body)
+
+		if !cache.NoCache(r.Header) {
+			if entry, ok := responseCache.Get(key); ok {
+				writeCacheEntry(w, r, entry, "HIT")
+				return
+			}
+		}
+
+		result, _, _ := cacheGroup.Do(key, func() (interface{}, error) {
+			rec := &responseRecorder{header: make(http.Header)}
+			next.ServeHTTP(rec, r)
+
+			entry := &cache.Entry{
+				StatusCode: rec.status,
+				Header:     rec.header,
+				Body:       rec.body.Bytes(),
+				StoredAt:   time.Now(),
+			}
+			entry.ExpiresAt = entry.StoredAt.Add(cache.TTL(entry.Header, rule.CacheTTL(cacheDefaultTTL)))
+
+			if entry.StatusCode < 400 && !cache.NoStore(entry.Header) {
+				responseCache.Set(key, entry)
+			}
+
+			return entry, nil
+		})
+
+		writeCacheEntry(w, r, result.(*cache.Entry), "MISS")
+	})
+}
+
+// responseRecorder captures a handler's response instead of writing it straight to the client,
+// so cacheMiddleware can store it before (or instead of) sending it on.
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *responseRecorder) Header() http.Header { return rec.header }
+
+func (rec *responseRecorder) WriteHeader(status int) { rec.status = status }
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+
+	return rec.body.Write(b)
+}
+
+// writeCacheEntry writes a cached (or just-cached) response to w, reporting the cache outcome
+// via X-Cache and the entry's age via Age, per RFC 7234. It mints a fresh X-Request-Id for r
+// rather than replaying whichever upstream request originally populated the cache entry, so a
+// cache HIT doesn't hand two unrelated callers the same trace id.
+func writeCacheEntry(w http.ResponseWriter, r *http.Request, entry *cache.Entry, cacheStatus string) {
+	for key, values := range entry.Header {
+		if key == requestIDHeader {
+			continue
+		}
+
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+
+	w.Header().Set(requestIDHeader, requestID(r))
+	w.Header().Set("X-Cache", cacheStatus)
+	w.Header().Set("Age", strconv.Itoa(int(entry.Age(time.Now()).Seconds())))
+	w.WriteHeader(entry.StatusCode)
+	w.Write(entry.Body)
+}
+
+// statusResponseWriter wraps http.ResponseWriter to capture the status code and byte count
+// written for a request, neither of which the standard interface exposes after the fact.
+type statusResponseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+
+	// principal is set by authenticate once a backend succeeds; nil for unauthenticated requests.
+	principal *authn.Principal
+}
+
+func (w *statusResponseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+// accessLogMiddleware emits one access log record per request, in the format named by
+// ACCESS_LOG_FORMAT ("common", "combined", or "json"; defaults to "combined"), using the
+// authenticated Principal the authenticate middleware recorded on the wrapped writer.
+func accessLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusResponseWriter{ResponseWriter: w}
+
+		next.ServeHTTP(sw, r)
+
+		logs.Access(formatAccessRecord(sw, r, time.Since(start)))
+	})
+}
+
+func formatAccessRecord(sw *statusResponseWriter, r *http.Request, latency time.Duration) string {
+	userId := ""
+	role := ""
+	if sw.principal != nil {
+		userId = sw.principal.Subject
+		role = sw.principal.Role
+	}
+
+	switch strings.ToLower(os.Getenv("ACCESS_LOG_FORMAT")) {
+	case "json":
+		return formatAccessJSON(sw, r, latency, userId, role)
+	case "common":
+		return formatAccessCommon(sw, r)
+	default:
+		return formatAccessCombined(sw, r, latency, userId, role)
+	}
+}
+
+// formatAccessCommon renders an Apache Common Log Format line.
+func formatAccessCommon(sw *statusResponseWriter, r *http.Request) string {
+	return fmt.Sprintf("%s - - [%s] %q %d %d",
+		r.RemoteAddr, time.Now().Format("02/Jan/2006:15:04:05 -0700"), requestLine(r), sw.status, sw.bytes)
+}
+
+// formatAccessCombined extends Common with referer and user-agent, plus the authenticated
+// user id/role and latency appended as trailing fields so throttling can be traced back to a
+// caller without cross-referencing the JWT.
+func formatAccessCombined(sw *statusResponseWriter, r *http.Request, latency time.Duration, userId, role string) string {
+	return fmt.Sprintf("%s %q %q %s %s %dms",
+		formatAccessCommon(sw, r), r.Referer(), r.UserAgent(), userId, role, latency.Milliseconds())
+}
+
+func formatAccessJSON(sw *statusResponseWriter, r *http.Request, latency time.Duration, userId, role string) string {
+	record := map[string]any{
+		"time":        time.Now().Format(time.RFC3339),
+		"method":      r.Method,
+		"path":        r.URL.Path,
+		"status":      sw.status,
+		"bytes":       sw.bytes,
+		"latency_ms":  latency.Milliseconds(),
+		"remote_addr": r.RemoteAddr,
+		"user_agent":  r.UserAgent(),
+		"referer":     r.Referer(),
+		"user_id":     userId,
+		"role":        role,
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		logs.ErrorF("Error marshaling access log record: %v", err)
+		return ""
+	}
+
+	return string(line)
+}
+
+func requestLine(r *http.Request) string {
+	return fmt.Sprintf("%s %s %s", r.Method, r.URL.RequestURI(), r.Proto)
+}
+
 // endregion
 
 // region Handler
 
-// Proxy handler to forward the request to OpenRouteService
+const requestIDHeader = "X-Request-Id"
+
+// hopByHopHeaders are stripped per RFC 7230 6.1 before forwarding in either direction: they
+// describe the connection to one peer and must not be passed on to the next.
+var hopByHopHeaders = []string{
+	"Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization",
+	"TE", "Trailers", "Transfer-Encoding", "Upgrade",
+}
+
+// upstreamClient is shared across requests so upstream connections are pooled and reused
+// instead of the proxy dialing a fresh one per request.
+var upstreamClient = &http.Client{
+	Transport: &http.Transport{
+		MaxIdleConnsPerHost: 100,
+		IdleConnTimeout:     90 * time.Second,
+		ForceAttemptHTTP2:   true,
+	},
+}
+
+// stripHopByHopHeaders removes the headers RFC 7230 6.1 says are specific to one connection,
+// including any additional ones the Connection header itself names.
+func stripHopByHopHeaders(h http.Header) {
+	if connection := h.Get("Connection"); connection != "" {
+		for _, name := range strings.Split(connection, ",") {
+			h.Del(strings.TrimSpace(name))
+		}
+	}
+
+	for _, name := range hopByHopHeaders {
+		h.Del(name)
+	}
+}
+
+// setForwardedHeaders appends this hop's client address to X-Forwarded-For and sets
+// X-Forwarded-Proto/X-Forwarded-Host so the upstream can see the original request.
+func setForwardedHeaders(req, orig *http.Request) {
+	clientIP := orig.RemoteAddr
+	if host, _, err := net.SplitHostPort(orig.RemoteAddr); err == nil {
+		clientIP = host
+	}
+
+	if prior := req.Header.Get("X-Forwarded-For"); prior != "" {
+		req.Header.Set("X-Forwarded-For", prior+", "+clientIP)
+	} else {
+		req.Header.Set("X-Forwarded-For", clientIP)
+	}
+
+	proto := "http"
+	if orig.TLS != nil {
+		proto = "https"
+	}
+	req.Header.Set("X-Forwarded-Proto", proto)
+	req.Header.Set("X-Forwarded-Host", orig.Host)
+}
+
+// requestID returns the caller's request-id header if it set one, otherwise mints a new one so
+// the request can still be traced through the proxy and upstream logs.
+func requestID(orig *http.Request) string {
+	if id := orig.Header.Get(requestIDHeader); id != "" {
+		return id
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+
+	return hex.EncodeToString(buf)
+}
+
+// Proxy handler to forward the request to the upstream routeMiddleware matched for this request
 func proxyHandler(w http.ResponseWriter, r *http.Request) {
-	targetURL := targetDomain + r.URL.Path
+	rule, _ := ruleFromContext(r.Context())
+
+	targetURL := rule.Upstream + r.URL.Path
+	if r.URL.RawQuery != "" {
+		targetURL += "?" + r.URL.RawQuery
+	}
+
+	ctx := r.Context()
+	if timeout, ok := rule.Timeout(); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
 
 	// Forward the request
-	req, err := http.NewRequest(r.Method, targetURL, r.Body)
+	req, err := http.NewRequestWithContext(ctx, r.Method, targetURL, r.Body)
 	if err != nil {
 		http.Error(w, "Error creating request", http.StatusInternalServerError)
 		logs.ErrorF("Error creating request: %v", err)
 		return
 	}
 
-	// Copy headers from original request
-	for key, values := range r.Header {
-		for _, value := range values {
-			req.Header.Add(key, value)
-		}
-	}
+	req.Header = r.Header.Clone()
+	stripHopByHopHeaders(req.Header)
+	setForwardedHeaders(req, r)
+
+	id := requestID(r)
+	req.Header.Set(requestIDHeader, id)
+	w.Header().Set(requestIDHeader, id)
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := upstreamClient.Do(req)
 	if err != nil {
 		http.Error(w, "Error forwarding request", http.StatusBadGateway)
 		logs.ErrorF("Error forwarding request: %v", err)
@@ -114,6 +620,7 @@ func proxyHandler(w http.ResponseWriter, r *http.Request) {
 	defer resp.Body.Close()
 
 	// Copy response headers and body
+	stripHopByHopHeaders(resp.Header)
 	for key, values := range resp.Header {
 		for _, value := range values {
 			w.Header().Add(key, value)
@@ -121,7 +628,8 @@ func proxyHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	w.WriteHeader(resp.StatusCode)
 	if _, err = io.Copy(w, resp.Body); err != nil {
-		http.Error(w, "Error proxying response", http.StatusInternalServerError)
+		// Headers and a status code are already on the wire at this point, so all we can do
+		// is record the failure, not report it back to the client.
 		logs.ErrorF("Error proxying response: %v", err)
 	}
 }
@@ -131,21 +639,67 @@ func proxyHandler(w http.ResponseWriter, r *http.Request) {
 func main() {
 	// Initialize things
 	jwtSecret = []byte(os.Getenv("JWT_SIGN_KEY"))
-	targetDomain = os.Getenv("TARGET_DOMAIN")
-	logs = logger.NewLogger()
+	logs = logger.NewLogger(loggerConfigFromEnv())
 	logs.StartListener()
 
-	// Define the proxy route
-	http.Handle("/", validateJWT(http.HandlerFunc(proxyHandler)))
+	var err error
+	authenticator, err = buildAuthenticator()
+	if err != nil {
+		log.Fatalf("Error configuring auth backends: %v", err)
+	}
+
+	rulesPath := os.Getenv("RULES_FILE")
+	if rulesPath == "" {
+		rulesPath = "rules.yaml"
+	}
+
+	rulesRouter, err = rules.Load(rulesPath)
+	if err != nil {
+		log.Fatalf("Error loading rules: %v", err)
+	}
+
+	cacheDefaultTTL = 60 * time.Second
+	if ttlSeconds, err := strconv.Atoi(os.Getenv("CACHE_DEFAULT_TTL_SECONDS")); err == nil {
+		cacheDefaultTTL = time.Duration(ttlSeconds) * time.Second
+	}
+
+	if redisAddr := os.Getenv("REDIS_ADDR"); redisAddr != "" {
+		responseCache = cache.NewRedisStore(redisAddr, logs)
+	} else {
+		responseCache = cache.NewInProcessStore()
+	}
+
+	rateLimitConfig := defaultRateLimitConfig()
+	if rateLimitPath := os.Getenv("RATE_LIMIT_FILE"); rateLimitPath != "" {
+		loaded, err := ratelimit.Load(rateLimitPath)
+		if err != nil {
+			log.Fatalf("Error loading rate limits: %v", err)
+		}
+
+		rateLimitConfig = loaded
+	}
+	rateLimiter = ratelimit.NewLimiter(rateLimitConfig)
+
+	// Define the proxy route. accessLogMiddleware wraps authenticate, not the other way
+	// around, so a failed auth attempt (bad token, bad Basic creds, missing client cert) still
+	// produces an access log record instead of vanishing before logging ever runs.
+	http.Handle("/", accessLogMiddleware(authenticate(routeMiddleware(rateLimitMiddleware(cacheMiddleware(http.HandlerFunc(proxyHandler)))))))
 
 	if os.Getenv("APP_ENV") == "PROD" {
 		// Get ssl file paths
 		sslKeyPath := os.Getenv("SSL_KEY_PATH")
 		sslCertPath := os.Getenv("SSL_CERT_PATH")
 
+		tlsConfig, err := buildTLSConfig()
+		if err != nil {
+			log.Fatalf("Error configuring TLS: %v", err)
+		}
+
+		server := &http.Server{Addr: ":443", TLSConfig: tlsConfig}
+
 		// Start the server with SSL
 		log.Println("Proxy server running on port 443 with SSL")
-		if err := http.ListenAndServeTLS(":443", sslCertPath, sslKeyPath, nil); err != nil {
+		if err := server.ListenAndServeTLS(sslCertPath, sslKeyPath); err != nil {
 			log.Fatal(err)
 		}
 		return
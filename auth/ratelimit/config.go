@@ -0,0 +1,47 @@
+// Package ratelimit enforces token-bucket rate limits per authenticated principal and route, on
+// top of a global per-IP bucket, so one noisy caller can't exhaust the ORS quota shared with
+// everyone else.
+package ratelimit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Limit is a token-bucket rate: RPS tokens refill per second, up to Burst tokens banked.
+type Limit struct {
+	RPS   float64 `yaml:"rps" json:"rps"`
+	Burst int     `yaml:"burst" json:"burst"`
+}
+
+// Config holds per-role limits, optional per-route overrides keyed by path prefix (so, say,
+// CLIENT can be limited more tightly than COURIER on the same route), and a global per-IP
+// fallback limit that applies regardless of role.
+type Config struct {
+	Global Limit                       `yaml:"global" json:"global"`
+	Roles  map[string]Limit            `yaml:"roles" json:"roles"`
+	Routes map[string]map[string]Limit `yaml:"routes" json:"routes"`
+}
+
+// Load reads a rate limit config file, parsed as YAML unless its extension is ".json".
+func Load(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("reading rate limit file %s: %w", path, err)
+	}
+
+	var cfg Config
+	if strings.HasSuffix(path, ".json") {
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return Config{}, fmt.Errorf("parsing rate limit file %s: %w", path, err)
+		}
+	} else if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("parsing rate limit file %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
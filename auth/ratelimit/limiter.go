@@ -0,0 +1,138 @@
+package ratelimit
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Result carries a rate-limit decision and what it takes to populate the response headers.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastUsed time.Time
+}
+
+// Limiter enforces a token-bucket limit per (principal, route) key, plus a global per-IP
+// bucket, with idle buckets periodically garbage collected.
+type Limiter struct {
+	cfg Config
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+func NewLimiter(cfg Config) *Limiter {
+	l := &Limiter{cfg: cfg, buckets: make(map[string]*bucket)}
+	go l.gc(10 * time.Minute)
+
+	return l
+}
+
+// Allow decides whether a request from principalKey/role, against route, from remoteAddr may
+// proceed. It checks the principal+route bucket and the global per-IP bucket together and
+// rejects if either is exhausted.
+func (l *Limiter) Allow(principalKey, role, route, remoteAddr string) Result {
+	limit := l.limitFor(role, route)
+	principalBucket := l.bucketFor("principal:"+principalKey+":"+route, limit)
+	globalBucket := l.bucketFor("ip:"+remoteAddr, l.cfg.Global)
+
+	now := time.Now()
+	principalReservation := principalBucket.limiter.ReserveN(now, 1)
+	globalReservation := globalBucket.limiter.ReserveN(now, 1)
+
+	delay := principalReservation.DelayFrom(now)
+	if globalDelay := globalReservation.DelayFrom(now); globalDelay > delay {
+		delay = globalDelay
+	}
+
+	remaining := int(principalBucket.limiter.TokensAt(now))
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	if delay == 0 {
+		return Result{Allowed: true, Limit: limit.Burst, Remaining: remaining}
+	}
+
+	// Rejected: give back the tokens the reservations speculatively took.
+	principalReservation.CancelAt(now)
+	globalReservation.CancelAt(now)
+
+	return Result{Allowed: false, Limit: limit.Burst, RetryAfter: delay}
+}
+
+// limitFor resolves the limit for role/route: the most specific Routes override for role wins,
+// falling back to the role's default, then the global limit. Routes is a map, so its iteration
+// order is randomized; every matching prefix that overrides role is compared and the longest one
+// kept, so "most specific wins" is actually true from one request to the next, not just on
+// whichever process happens to iterate the right way.
+func (l *Limiter) limitFor(role, route string) Limit {
+	var bestPrefix string
+	var bestOverrides map[string]Limit
+	for prefix, overrides := range l.cfg.Routes {
+		if !strings.HasPrefix(route, prefix) {
+			continue
+		}
+
+		if _, ok := overrides[role]; !ok {
+			continue
+		}
+
+		if len(prefix) > len(bestPrefix) {
+			bestPrefix, bestOverrides = prefix, overrides
+		}
+	}
+
+	if limit, ok := bestOverrides[role]; ok {
+		return limit
+	}
+
+	if limit, ok := l.cfg.Roles[role]; ok {
+		return limit
+	}
+
+	return l.cfg.Global
+}
+
+func (l *Limiter) bucketFor(key string, limit Limit) *bucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(rate.Limit(limit.RPS), limit.Burst)}
+		l.buckets[key] = b
+	}
+
+	b.lastUsed = time.Now()
+
+	return b
+}
+
+// gc periodically drops buckets that haven't been touched in idleAfter, so the map doesn't
+// grow without bound as principals and routes come and go.
+func (l *Limiter) gc(idleAfter time.Duration) {
+	ticker := time.NewTicker(idleAfter)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		cutoff := time.Now().Add(-idleAfter)
+
+		l.mu.Lock()
+		for key, b := range l.buckets {
+			if b.lastUsed.Before(cutoff) {
+				delete(l.buckets, key)
+			}
+		}
+		l.mu.Unlock()
+	}
+}
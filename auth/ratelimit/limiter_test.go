@@ -0,0 +1,70 @@
+package ratelimit
+
+import "testing"
+
+func TestLimitForMostSpecificRouteWins(t *testing.T) {
+	cfg := Config{
+		Global: Limit{RPS: 20, Burst: 40},
+		Roles: map[string]Limit{
+			"CLIENT": {RPS: 5, Burst: 10},
+		},
+		Routes: map[string]map[string]Limit{
+			"/v2": {
+				"CLIENT": {RPS: 2, Burst: 4},
+			},
+			"/v2/matrix": {
+				"CLIENT": {RPS: 1, Burst: 2},
+			},
+		},
+	}
+
+	l := &Limiter{cfg: cfg}
+
+	// Run repeatedly: map iteration order is randomized per-process, so a flaky fix would
+	// only fail some fraction of the time.
+	for i := 0; i < 50; i++ {
+		got := l.limitFor("CLIENT", "/v2/matrix")
+		if want := (Limit{RPS: 1, Burst: 2}); got != want {
+			t.Fatalf("limitFor(CLIENT, /v2/matrix) = %+v, want %+v", got, want)
+		}
+	}
+}
+
+func TestLimitForFallsBackToLessSpecificRoute(t *testing.T) {
+	cfg := Config{
+		Global: Limit{RPS: 20, Burst: 40},
+		Routes: map[string]map[string]Limit{
+			"/v2": {
+				"CLIENT": {RPS: 2, Burst: 4},
+			},
+			"/v2/matrix": {
+				"COURIER": {RPS: 1, Burst: 2},
+			},
+		},
+	}
+
+	l := &Limiter{cfg: cfg}
+
+	if got, want := l.limitFor("CLIENT", "/v2/matrix"), (Limit{RPS: 2, Burst: 4}); got != want {
+		t.Fatalf("limitFor(CLIENT, /v2/matrix) = %+v, want %+v", got, want)
+	}
+}
+
+func TestLimitForFallsBackToRoleThenGlobal(t *testing.T) {
+	cfg := Config{
+		Global: Limit{RPS: 20, Burst: 40},
+		Roles: map[string]Limit{
+			"CLIENT": {RPS: 5, Burst: 10},
+		},
+	}
+
+	l := &Limiter{cfg: cfg}
+
+	if got, want := l.limitFor("CLIENT", "/v2/directions"), (Limit{RPS: 5, Burst: 10}); got != want {
+		t.Fatalf("limitFor(CLIENT, /v2/directions) = %+v, want %+v", got, want)
+	}
+
+	if got, want := l.limitFor("VENDOR", "/v2/directions"), cfg.Global; got != want {
+		t.Fatalf("limitFor(VENDOR, /v2/directions) = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,142 @@
+// Package rules loads the per-route policy that decides which upstream a request is forwarded
+// to and which role/capabilities a caller needs to reach it, so one proxy instance can front
+// several ORS endpoints (matrix, isochrones, directions, ...) with different auth policies.
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"main/authn"
+)
+
+// DefaultTimeout bounds how long proxyHandler waits on the upstream for a non-streaming Rule
+// that doesn't set its own TimeoutSeconds, so a slow or hung upstream can't pin a connection
+// open indefinitely just because an operator forgot to configure a deadline.
+const DefaultTimeout = 10 * time.Second
+
+// Rule matches requests by method and path prefix and describes where to forward them and who
+// is allowed to. The first Rule in the list whose PathPrefix and Methods match wins.
+type Rule struct {
+	PathPrefix           string   `yaml:"path_prefix" json:"path_prefix"`
+	Methods              []string `yaml:"methods" json:"methods"`
+	Upstream             string   `yaml:"upstream" json:"upstream"`
+	RequiredCapabilities []string `yaml:"required_capabilities" json:"required_capabilities"`
+	RequiredRoles        []string `yaml:"required_roles" json:"required_roles"`
+
+	// TimeoutSeconds bounds how long proxyHandler waits on the upstream for this rule. Zero
+	// means DefaultTimeout applies.
+	TimeoutSeconds int `yaml:"timeout_seconds" json:"timeout_seconds"`
+
+	// Stream marks an endpoint as long-lived (e.g. a streaming response), so TimeoutSeconds is
+	// ignored and proxyHandler relies solely on the client's context for cancellation.
+	Stream bool `yaml:"stream" json:"stream"`
+
+	// Cacheable opts a rule into the response cache for its idempotent requests.
+	Cacheable bool `yaml:"cacheable" json:"cacheable"`
+
+	// CacheTTLSeconds overrides the cache's default TTL when the upstream response carries no
+	// Cache-Control/Expires of its own. Zero means use the cache's configured default.
+	CacheTTLSeconds int `yaml:"cache_ttl_seconds" json:"cache_ttl_seconds"`
+}
+
+// CacheTTL returns the TTL to fall back to for this rule when the upstream response specifies
+// none itself.
+func (r Rule) CacheTTL(defaultTTL time.Duration) time.Duration {
+	if r.CacheTTLSeconds <= 0 {
+		return defaultTTL
+	}
+
+	return time.Duration(r.CacheTTLSeconds) * time.Second
+}
+
+// Timeout returns the deadline to bound the upstream request by, if any: Stream rules report no
+// deadline so proxyHandler relies solely on the client's own context, while every other rule
+// gets its configured TimeoutSeconds or, if unset, DefaultTimeout.
+func (r Rule) Timeout() (time.Duration, bool) {
+	if r.Stream {
+		return 0, false
+	}
+
+	if r.TimeoutSeconds <= 0 {
+		return DefaultTimeout, true
+	}
+
+	return time.Duration(r.TimeoutSeconds) * time.Second, true
+}
+
+// Allows reports whether principal may issue method against this Rule: its role must be in
+// RequiredRoles (when set), and for every resource in RequiredCapabilities the principal must
+// hold "<resource>:read" for safe methods or "<resource>:write" otherwise.
+func (r Rule) Allows(principal authn.Principal, method string) bool {
+	if len(r.RequiredRoles) > 0 && !authn.Contains(r.RequiredRoles, principal.Role) {
+		return false
+	}
+
+	verb := verbForMethod(method)
+	for _, resource := range r.RequiredCapabilities {
+		if !authn.Contains(principal.Capabilities, resource+":"+verb) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// verbForMethod maps an HTTP method to the capability verb it requires, e.g. GET needs
+// "routing:read" while POST needs "routing:write".
+func verbForMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return "read"
+	default:
+		return "write"
+	}
+}
+
+// Router dispatches requests to the first matching Rule, in the order they were configured.
+type Router struct {
+	rules []Rule
+}
+
+// Load reads a rules file, parsed as YAML unless its extension is ".json".
+func Load(path string) (*Router, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading rules file %s: %w", path, err)
+	}
+
+	var parsedRules []Rule
+	if strings.HasSuffix(path, ".json") {
+		if err := json.Unmarshal(data, &parsedRules); err != nil {
+			return nil, fmt.Errorf("parsing rules file %s: %w", path, err)
+		}
+	} else if err := yaml.Unmarshal(data, &parsedRules); err != nil {
+		return nil, fmt.Errorf("parsing rules file %s: %w", path, err)
+	}
+
+	return &Router{rules: parsedRules}, nil
+}
+
+// Match returns the first configured Rule whose PathPrefix and Methods match the request.
+func (rt *Router) Match(method, path string) (Rule, bool) {
+	for _, rule := range rt.rules {
+		if !strings.HasPrefix(path, rule.PathPrefix) {
+			continue
+		}
+
+		if len(rule.Methods) > 0 && !authn.Contains(rule.Methods, method) {
+			continue
+		}
+
+		return rule, true
+	}
+
+	return Rule{}, false
+}
@@ -0,0 +1,114 @@
+package rules
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"main/authn"
+)
+
+func TestRuleAllowsChecksRoleAndCapabilities(t *testing.T) {
+	rule := Rule{
+		RequiredRoles:        []string{"ADMIN", "MANAGER"},
+		RequiredCapabilities: []string{"matrix"},
+	}
+
+	cases := []struct {
+		name      string
+		principal authn.Principal
+		method    string
+		want      bool
+	}{
+		{
+			name:      "role and capability allowed",
+			principal: authn.Principal{Role: "ADMIN", Capabilities: []string{"matrix:write"}},
+			method:    http.MethodPost,
+			want:      true,
+		},
+		{
+			name:      "role not in RequiredRoles",
+			principal: authn.Principal{Role: "CLIENT", Capabilities: []string{"matrix:write"}},
+			method:    http.MethodPost,
+			want:      false,
+		},
+		{
+			name:      "missing capability",
+			principal: authn.Principal{Role: "ADMIN"},
+			method:    http.MethodPost,
+			want:      false,
+		},
+		{
+			name:      "read-only method only needs the read verb",
+			principal: authn.Principal{Role: "ADMIN", Capabilities: []string{"matrix:read"}},
+			method:    http.MethodGet,
+			want:      true,
+		},
+		{
+			name:      "write method does not accept a read-only capability",
+			principal: authn.Principal{Role: "ADMIN", Capabilities: []string{"matrix:read"}},
+			method:    http.MethodPost,
+			want:      false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := rule.Allows(tc.principal, tc.method); got != tc.want {
+				t.Errorf("Allows() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRuleAllowsWithNoRequiredRoles(t *testing.T) {
+	rule := Rule{RequiredCapabilities: []string{"routing"}}
+
+	principal := authn.Principal{Role: "ANYONE", Capabilities: []string{"routing:read"}}
+	if !rule.Allows(principal, http.MethodGet) {
+		t.Errorf("Allows() = false, want true when RequiredRoles is empty")
+	}
+}
+
+func TestRouterMatchReturnsFirstMatchingRule(t *testing.T) {
+	router := &Router{rules: []Rule{
+		{PathPrefix: "/v2/matrix", Methods: []string{http.MethodPost}, Upstream: "matrix-upstream"},
+		{PathPrefix: "/v2", Methods: []string{http.MethodGet, http.MethodPost}, Upstream: "catch-all-upstream"},
+	}}
+
+	rule, ok := router.Match(http.MethodPost, "/v2/matrix")
+	if !ok || rule.Upstream != "matrix-upstream" {
+		t.Fatalf("Match() = %+v, %v; want the /v2/matrix rule", rule, ok)
+	}
+
+	rule, ok = router.Match(http.MethodGet, "/v2/directions")
+	if !ok || rule.Upstream != "catch-all-upstream" {
+		t.Fatalf("Match() = %+v, %v; want the /v2 rule", rule, ok)
+	}
+
+	if _, ok := router.Match(http.MethodPost, "/v2/directions"); !ok {
+		t.Fatalf("Match() = _, false; want a match since /v2 allows POST too")
+	}
+
+	if _, ok := router.Match(http.MethodGet, "/v1/directions"); ok {
+		t.Fatalf("Match() = _, true; want no match for an unconfigured prefix")
+	}
+}
+
+func TestRuleTimeoutDefaultsWhenUnset(t *testing.T) {
+	rule := Rule{}
+	timeout, ok := rule.Timeout()
+	if !ok || timeout != DefaultTimeout {
+		t.Fatalf("Timeout() = %v, %v; want %v, true", timeout, ok, DefaultTimeout)
+	}
+
+	rule = Rule{TimeoutSeconds: 5}
+	if timeout, ok := rule.Timeout(); !ok || timeout != 5*time.Second {
+		t.Fatalf("Timeout() = %v, %v; want 5s, true", timeout, ok)
+	}
+
+	rule = Rule{Stream: true}
+	if timeout, ok := rule.Timeout(); ok || timeout != 0 {
+		t.Fatalf("Timeout() = %v, %v; want 0, false for a streaming rule", timeout, ok)
+	}
+}